@@ -0,0 +1,122 @@
+package amazonq
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+// EncodeMessage 按 AWS Event Stream 线上格式编码一条消息：
+// 4 字节总长度、4 字节头部长度、4 字节 prelude CRC32、头部区、payload、4 字节消息 CRC32
+// 与 ParseMessage 互为逆操作
+func EncodeMessage(headers map[string]HeaderValue, payload []byte) ([]byte, error) {
+	headersData, err := encodeHeaders(headers)
+	if err != nil {
+		return nil, err
+	}
+
+	headersLength := uint32(len(headersData))
+	totalLength := uint32(12) + headersLength + uint32(len(payload)) + 4
+
+	buf := make([]byte, totalLength)
+	binary.BigEndian.PutUint32(buf[0:4], totalLength)
+	binary.BigEndian.PutUint32(buf[4:8], headersLength)
+	binary.BigEndian.PutUint32(buf[8:12], crc32.ChecksumIEEE(buf[0:8]))
+
+	copy(buf[12:12+headersLength], headersData)
+	copy(buf[12+headersLength:totalLength-4], payload)
+
+	binary.BigEndian.PutUint32(buf[totalLength-4:totalLength], crc32.ChecksumIEEE(buf[0:totalLength-4]))
+
+	return buf, nil
+}
+
+// encodeHeaders 按 1 字节名称长度 / 1 字节值类型 / 2 字节值长度 的编码写出头部区，
+// 与 ParseTypedHeaders 读取的格式一致
+func encodeHeaders(headers map[string]HeaderValue) ([]byte, error) {
+	var buf bytes.Buffer
+	for name, value := range headers {
+		if len(name) > 255 {
+			return nil, fmt.Errorf("header name %q exceeds 255 bytes", name)
+		}
+		buf.WriteByte(byte(len(name)))
+		buf.WriteString(name)
+		buf.WriteByte(byte(value.Type))
+
+		encoded, err := encodeHeaderValue(value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(encoded)
+	}
+	return buf.Bytes(), nil
+}
+
+// encodeHeaderValue 按 value.Type 编码单个头部值。bool 类型的值完全由类型字节本身
+// 表达（0/1），不写入任何值数据
+func encodeHeaderValue(value HeaderValue) ([]byte, error) {
+	switch value.Type {
+	case HeaderValueTypeBoolTrue, HeaderValueTypeBoolFalse:
+		return nil, nil
+	case HeaderValueTypeByte:
+		return []byte{value.ByteVal}, nil
+	case HeaderValueTypeInt16:
+		b := make([]byte, 2)
+		binary.BigEndian.PutUint16(b, uint16(value.Int16Val))
+		return b, nil
+	case HeaderValueTypeInt32:
+		b := make([]byte, 4)
+		binary.BigEndian.PutUint32(b, uint32(value.Int32Val))
+		return b, nil
+	case HeaderValueTypeInt64:
+		b := make([]byte, 8)
+		binary.BigEndian.PutUint64(b, uint64(value.Int64Val))
+		return b, nil
+	case HeaderValueTypeByteArray:
+		return encodeLengthPrefixed(value.BytesVal)
+	case HeaderValueTypeString:
+		return encodeLengthPrefixed([]byte(value.StringVal))
+	case HeaderValueTypeTimestamp:
+		b := make([]byte, 8)
+		binary.BigEndian.PutUint64(b, uint64(value.TimeVal.UnixMilli()))
+		return b, nil
+	case HeaderValueTypeUUID:
+		return append([]byte{}, value.UUIDVal[:]...), nil
+	default:
+		return nil, fmt.Errorf("unknown header value type %d", value.Type)
+	}
+}
+
+// encodeLengthPrefixed 为 byte-array/string 类型的头部值写出 2 字节长度前缀
+func encodeLengthPrefixed(data []byte) ([]byte, error) {
+	if len(data) > 0xFFFF {
+		return nil, fmt.Errorf("header value exceeds 65535 bytes")
+	}
+	out := make([]byte, 2+len(data))
+	binary.BigEndian.PutUint16(out[0:2], uint16(len(data)))
+	copy(out[2:], data)
+	return out, nil
+}
+
+// EventStreamWriter 将消息以 AWS Event Stream 线上格式顺序写入底层 Writer
+type EventStreamWriter struct {
+	w io.Writer
+}
+
+// NewEventStreamWriter 基于 w 创建一个 EventStreamWriter
+func NewEventStreamWriter(w io.Writer) *EventStreamWriter {
+	return &EventStreamWriter{w: w}
+}
+
+// WriteMessage 编码并写出一条消息。这使得构造 mock 服务端和
+// 录制/回放测试夹具时无需手工拼接字节，也让解析器在单元测试中可往返验证
+func (sw *EventStreamWriter) WriteMessage(headers map[string]HeaderValue, payload []byte) error {
+	data, err := EncodeMessage(headers, payload)
+	if err != nil {
+		return err
+	}
+	_, err = sw.w.Write(data)
+	return err
+}
@@ -0,0 +1,77 @@
+package amazonq
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestEncodeMessage_RoundTripsThroughParseMessage(t *testing.T) {
+	ts := time.UnixMilli(1700000000000).UTC()
+	headers := map[string]HeaderValue{
+		":event-type":   {Type: HeaderValueTypeString, StringVal: "assistantResponseEvent"},
+		":message-type": {Type: HeaderValueTypeString, StringVal: "event"},
+		"stop":          {Type: HeaderValueTypeBoolTrue, BoolVal: true},
+		"retries":       {Type: HeaderValueTypeInt32, Int32Val: 3},
+		"sent-at":       {Type: HeaderValueTypeTimestamp, TimeVal: ts},
+	}
+	payload := []byte(`{"content":"hello"}`)
+
+	data, err := EncodeMessage(headers, payload)
+	if err != nil {
+		t.Fatalf("EncodeMessage: %v", err)
+	}
+
+	msg, err := ParseMessage(data)
+	if err != nil {
+		t.Fatalf("ParseMessage: %v", err)
+	}
+
+	if msg.Headers[":event-type"] != "assistantResponseEvent" {
+		t.Fatalf("unexpected event-type header: %q", msg.Headers[":event-type"])
+	}
+	if msg.Headers["stop"] != "true" {
+		t.Fatalf("unexpected stop header: %q", msg.Headers["stop"])
+	}
+	if msg.Headers["retries"] != "3" {
+		t.Fatalf("unexpected retries header: %q", msg.Headers["retries"])
+	}
+
+	headersLength := binary.BigEndian.Uint32(data[4:8])
+	typed, err := ParseTypedHeaders(data[12 : 12+headersLength])
+	if err != nil {
+		t.Fatalf("ParseTypedHeaders: %v", err)
+	}
+	if !typed["sent-at"].TimeVal.Equal(ts) {
+		t.Fatalf("unexpected timestamp round trip: %v", typed["sent-at"].TimeVal)
+	}
+
+	wantPayload := map[string]interface{}{"content": "hello"}
+	if !reflect.DeepEqual(msg.Payload, wantPayload) {
+		t.Fatalf("unexpected payload: %+v", msg.Payload)
+	}
+}
+
+func TestEventStreamWriter_WriteMessageMatchesEncodeMessage(t *testing.T) {
+	headers := map[string]HeaderValue{
+		":event-type": {Type: HeaderValueTypeString, StringVal: "toolUseEvent"},
+	}
+	payload := []byte(`{"toolUseId":"t1","name":"search","input":"{}","stop":true}`)
+
+	want, err := EncodeMessage(headers, payload)
+	if err != nil {
+		t.Fatalf("EncodeMessage: %v", err)
+	}
+
+	var buf bytes.Buffer
+	writer := NewEventStreamWriter(&buf)
+	if err := writer.WriteMessage(headers, payload); err != nil {
+		t.Fatalf("WriteMessage: %v", err)
+	}
+
+	if !bytes.Equal(buf.Bytes(), want) {
+		t.Fatalf("WriteMessage output differs from EncodeMessage output")
+	}
+}
@@ -1,12 +1,20 @@
 package amazonq
 
 import (
+	"encoding/base64"
 	"encoding/binary"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"hash/crc32"
 	"io"
+	"strconv"
+	"time"
 )
 
+// ErrChecksumMismatch 表示事件流消息的 CRC32 校验和与消息内容不匹配
+var ErrChecksumMismatch = errors.New("amazonq: event stream checksum mismatch")
+
 // EventStreamMessage 表示事件流中的单个消息
 type EventStreamMessage struct {
 	Headers     map[string]string
@@ -14,6 +22,63 @@ type EventStreamMessage struct {
 	TotalLength uint32
 }
 
+// HeaderValueType 表示 AWS Event Stream 头部值的线上类型字节
+type HeaderValueType byte
+
+const (
+	HeaderValueTypeBoolTrue  HeaderValueType = 0
+	HeaderValueTypeBoolFalse HeaderValueType = 1
+	HeaderValueTypeByte      HeaderValueType = 2
+	HeaderValueTypeInt16     HeaderValueType = 3
+	HeaderValueTypeInt32     HeaderValueType = 4
+	HeaderValueTypeInt64     HeaderValueType = 5
+	HeaderValueTypeByteArray HeaderValueType = 6
+	HeaderValueTypeString    HeaderValueType = 7
+	HeaderValueTypeTimestamp HeaderValueType = 8
+	HeaderValueTypeUUID      HeaderValueType = 9
+)
+
+// HeaderValue 是带有线上类型标签的事件流头部值，同一时刻只有与 Type 对应的字段有效
+type HeaderValue struct {
+	Type      HeaderValueType
+	BoolVal   bool
+	ByteVal   byte
+	Int16Val  int16
+	Int32Val  int32
+	Int64Val  int64
+	BytesVal  []byte
+	StringVal string
+	TimeVal   time.Time
+	UUIDVal   [16]byte
+}
+
+// String 将头部值按照其线上类型格式化为字符串，供 ParseHeaders 的兼容包装
+// 以及 ExtractEventInfo 等只需要字符串形式的调用方使用
+func (h HeaderValue) String() string {
+	switch h.Type {
+	case HeaderValueTypeBoolTrue, HeaderValueTypeBoolFalse:
+		return strconv.FormatBool(h.BoolVal)
+	case HeaderValueTypeByte:
+		return strconv.Itoa(int(h.ByteVal))
+	case HeaderValueTypeInt16:
+		return strconv.FormatInt(int64(h.Int16Val), 10)
+	case HeaderValueTypeInt32:
+		return strconv.FormatInt(int64(h.Int32Val), 10)
+	case HeaderValueTypeInt64:
+		return strconv.FormatInt(h.Int64Val, 10)
+	case HeaderValueTypeByteArray:
+		return base64.StdEncoding.EncodeToString(h.BytesVal)
+	case HeaderValueTypeString:
+		return h.StringVal
+	case HeaderValueTypeTimestamp:
+		return h.TimeVal.UTC().Format(time.RFC3339Nano)
+	case HeaderValueTypeUUID:
+		return fmt.Sprintf("%x-%x-%x-%x-%x", h.UUIDVal[0:4], h.UUIDVal[4:6], h.UUIDVal[6:8], h.UUIDVal[8:10], h.UUIDVal[10:16])
+	default:
+		return ""
+	}
+}
+
 // EventInfo 存储解析后的事件信息
 type EventInfo struct {
 	EventType   string
@@ -28,53 +93,116 @@ type SSEEvent struct {
 	Data  interface{}
 }
 
-// ParseHeaders 解析事件流消息的头部数据
+// ParseTypedHeaders 解析事件流消息的头部数据，保留每个头部值的线上类型
 // 参数 headersData 为头部二进制数据
 // 返回解析后的头部键值对映射
-func ParseHeaders(headersData []byte) map[string]string {
-	headers := make(map[string]string)
+func ParseTypedHeaders(headersData []byte) (map[string]HeaderValue, error) {
+	headers := make(map[string]HeaderValue)
 	offset := 0
 
 	for offset < len(headersData) {
-		if offset >= len(headersData) {
-			break
-		}
 		nameLength := int(headersData[offset])
 		offset++
 
 		if offset+nameLength > len(headersData) {
-			break
+			return nil, fmt.Errorf("malformed header: name exceeds buffer")
 		}
 		name := string(headersData[offset : offset+nameLength])
 		offset += nameLength
 
 		if offset >= len(headersData) {
-			break
+			return nil, fmt.Errorf("malformed header: missing value type")
 		}
-		valueType := headersData[offset]
+		valueType := HeaderValueType(headersData[offset])
 		offset++
 
-		if offset+2 > len(headersData) {
-			break
+		value, n, err := parseHeaderValue(valueType, headersData[offset:])
+		if err != nil {
+			return nil, err
 		}
-		valueLength := binary.BigEndian.Uint16(headersData[offset : offset+2])
-		offset += 2
+		offset += n
 
-		if offset+int(valueLength) > len(headersData) {
-			break
-		}
+		headers[name] = value
+	}
+
+	return headers, nil
+}
 
-		var value string
-		if valueType == 7 {
-			value = string(headersData[offset : offset+int(valueLength)])
-		} else {
-			value = string(headersData[offset : offset+int(valueLength)])
+// parseHeaderValue 按照 valueType 解析单个头部值
+// 返回解析出的 HeaderValue 以及消耗的字节数
+func parseHeaderValue(valueType HeaderValueType, data []byte) (HeaderValue, int, error) {
+	switch valueType {
+	case HeaderValueTypeBoolTrue:
+		return HeaderValue{Type: valueType, BoolVal: true}, 0, nil
+	case HeaderValueTypeBoolFalse:
+		return HeaderValue{Type: valueType, BoolVal: false}, 0, nil
+	case HeaderValueTypeByte:
+		if len(data) < 1 {
+			return HeaderValue{}, 0, fmt.Errorf("malformed header: byte value too short")
 		}
+		return HeaderValue{Type: valueType, ByteVal: data[0]}, 1, nil
+	case HeaderValueTypeInt16:
+		if len(data) < 2 {
+			return HeaderValue{}, 0, fmt.Errorf("malformed header: int16 value too short")
+		}
+		return HeaderValue{Type: valueType, Int16Val: int16(binary.BigEndian.Uint16(data[:2]))}, 2, nil
+	case HeaderValueTypeInt32:
+		if len(data) < 4 {
+			return HeaderValue{}, 0, fmt.Errorf("malformed header: int32 value too short")
+		}
+		return HeaderValue{Type: valueType, Int32Val: int32(binary.BigEndian.Uint32(data[:4]))}, 4, nil
+	case HeaderValueTypeInt64:
+		if len(data) < 8 {
+			return HeaderValue{}, 0, fmt.Errorf("malformed header: int64 value too short")
+		}
+		return HeaderValue{Type: valueType, Int64Val: int64(binary.BigEndian.Uint64(data[:8]))}, 8, nil
+	case HeaderValueTypeByteArray, HeaderValueTypeString:
+		if len(data) < 2 {
+			return HeaderValue{}, 0, fmt.Errorf("malformed header: value length too short")
+		}
+		valueLength := int(binary.BigEndian.Uint16(data[:2]))
+		if len(data) < 2+valueLength {
+			return HeaderValue{}, 0, fmt.Errorf("malformed header: value exceeds buffer")
+		}
+		raw := data[2 : 2+valueLength]
+		if valueType == HeaderValueTypeString {
+			return HeaderValue{Type: valueType, StringVal: string(raw)}, 2 + valueLength, nil
+		}
+		bytesVal := make([]byte, valueLength)
+		copy(bytesVal, raw)
+		return HeaderValue{Type: valueType, BytesVal: bytesVal}, 2 + valueLength, nil
+	case HeaderValueTypeTimestamp:
+		if len(data) < 8 {
+			return HeaderValue{}, 0, fmt.Errorf("malformed header: timestamp value too short")
+		}
+		ms := int64(binary.BigEndian.Uint64(data[:8]))
+		return HeaderValue{Type: valueType, TimeVal: time.UnixMilli(ms).UTC()}, 8, nil
+	case HeaderValueTypeUUID:
+		if len(data) < 16 {
+			return HeaderValue{}, 0, fmt.Errorf("malformed header: uuid value too short")
+		}
+		var uuid [16]byte
+		copy(uuid[:], data[:16])
+		return HeaderValue{Type: valueType, UUIDVal: uuid}, 16, nil
+	default:
+		return HeaderValue{}, 0, fmt.Errorf("malformed header: unknown value type %d", valueType)
+	}
+}
 
-		offset += int(valueLength)
-		headers[name] = value
+// ParseHeaders 解析事件流消息的头部数据，返回字符串形式的键值对
+// 这是 ParseTypedHeaders 的薄包装，保留给不需要具体线上类型的旧调用方
+// 参数 headersData 为头部二进制数据
+// 返回解析后的头部键值对映射
+func ParseHeaders(headersData []byte) map[string]string {
+	typed, err := ParseTypedHeaders(headersData)
+	if err != nil {
+		return map[string]string{}
 	}
 
+	headers := make(map[string]string, len(typed))
+	for name, value := range typed {
+		headers[name] = value.String()
+	}
 	return headers
 }
 
@@ -89,10 +217,29 @@ func ParseMessage(data []byte) (*EventStreamMessage, error) {
 	totalLength := binary.BigEndian.Uint32(data[0:4])
 	headersLength := binary.BigEndian.Uint32(data[4:8])
 
+	// minFrameSize 是 4 字节总长度 + 4 字节头部长度 + 4 字节 prelude CRC +
+	// 4 字节消息 CRC，是任何合法帧都必须满足的下限，还需要容纳 headersLength
+	// 声明的头部数据，否则下面的切片会越界甚至产生负长度区间
+	const minFrameSize = 16
+	if totalLength < minFrameSize || uint64(12)+uint64(headersLength)+4 > uint64(totalLength) {
+		return nil, fmt.Errorf("invalid message: totalLength %d too small for headersLength %d", totalLength, headersLength)
+	}
+
+	preludeCRC := binary.BigEndian.Uint32(data[8:12])
+
+	if crc32.ChecksumIEEE(data[0:8]) != preludeCRC {
+		return nil, ErrChecksumMismatch
+	}
+
 	if len(data) < int(totalLength) {
 		return nil, fmt.Errorf("incomplete message: expected %d bytes, got %d", totalLength, len(data))
 	}
 
+	messageCRC := binary.BigEndian.Uint32(data[totalLength-4 : totalLength])
+	if crc32.ChecksumIEEE(data[0:totalLength-4]) != messageCRC {
+		return nil, ErrChecksumMismatch
+	}
+
 	headersData := data[12 : 12+headersLength]
 	headers := ParseHeaders(headersData)
 
@@ -146,7 +293,9 @@ func ParseStream(reader io.Reader, eventChan chan<- *EventStreamMessage) error {
 
 				message, parseErr := ParseMessage(messageData)
 				if parseErr != nil {
-					continue
+					// 帧级别的解析错误（例如 ErrChecksumMismatch）说明流已损坏，
+					// 继续读取后续字节没有意义，直接把错误返回给调用方
+					return parseErr
 				}
 
 				eventChan <- message
@@ -0,0 +1,59 @@
+package amazonq
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"testing"
+)
+
+func TestParseMessage_RejectsChecksumMismatch(t *testing.T) {
+	data, err := EncodeMessage(map[string]HeaderValue{
+		":event-type": {Type: HeaderValueTypeString, StringVal: "assistantResponseEvent"},
+	}, []byte(`{"content":"hi"}`))
+	if err != nil {
+		t.Fatalf("EncodeMessage: %v", err)
+	}
+
+	corrupted := append([]byte(nil), data...)
+	corrupted[len(corrupted)-1] ^= 0xFF
+
+	if _, err := ParseMessage(corrupted); !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("expected ErrChecksumMismatch, got %v", err)
+	}
+}
+
+func TestParseMessage_RejectsUndersizedTotalLength(t *testing.T) {
+	// totalLength=12 claims a frame too small to leave room for the trailing
+	// message CRC, with the prelude CRC crafted to match so only the bounds
+	// check (not the checksum check) can catch it.
+	buf := make([]byte, 16)
+	binary.BigEndian.PutUint32(buf[0:4], 12)
+	binary.BigEndian.PutUint32(buf[4:8], 0)
+	binary.BigEndian.PutUint32(buf[8:12], crc32.ChecksumIEEE(buf[0:8]))
+	binary.BigEndian.PutUint32(buf[12:16], 0)
+
+	if _, err := ParseMessage(buf); err == nil {
+		t.Fatalf("expected an error for undersized totalLength, got nil")
+	}
+}
+
+func TestParseStream_StopsOnChecksumMismatch(t *testing.T) {
+	data, err := EncodeMessage(map[string]HeaderValue{
+		":event-type": {Type: HeaderValueTypeString, StringVal: "assistantResponseEvent"},
+	}, []byte(`{"content":"hi"}`))
+	if err != nil {
+		t.Fatalf("EncodeMessage: %v", err)
+	}
+	data[len(data)-1] ^= 0xFF
+
+	eventChan := make(chan *EventStreamMessage, 1)
+	err = ParseStream(bytes.NewReader(data), eventChan)
+	if !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("expected ErrChecksumMismatch from ParseStream, got %v", err)
+	}
+	if _, ok := <-eventChan; ok {
+		t.Fatalf("expected no messages to be emitted for a corrupted frame")
+	}
+}
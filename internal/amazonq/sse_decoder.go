@@ -0,0 +1,263 @@
+package amazonq
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"strings"
+)
+
+// EventType 表示 SSE 事件的类型
+type EventType string
+
+const (
+	EventMessageStart      EventType = "message_start"
+	EventContentBlockStart EventType = "content_block_start"
+	EventContentBlockDelta EventType = "content_block_delta"
+	EventContentBlockStop  EventType = "content_block_stop"
+	EventMessageDelta      EventType = "message_delta"
+	EventMessageStop       EventType = "message_stop"
+	EventPing              EventType = "ping"
+	EventError             EventType = "error"
+)
+
+// Usage 表示 token 使用量统计
+type Usage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+// Message 表示 message_start 事件携带的消息体
+type Message struct {
+	ID           string        `json:"id"`
+	Type         string        `json:"type"`
+	Role         string        `json:"role"`
+	Content      []interface{} `json:"content"`
+	Model        string        `json:"model"`
+	StopReason   *string       `json:"stop_reason"`
+	StopSequence *string       `json:"stop_sequence"`
+	Usage        Usage         `json:"usage"`
+}
+
+// MessageStartEvent 对应 message_start SSE 事件
+type MessageStartEvent struct {
+	Type    string  `json:"type"`
+	Message Message `json:"message"`
+}
+
+// ContentBlock 表示 content_block_start 携带的内容块
+type ContentBlock struct {
+	Type  string                 `json:"type"`
+	Text  string                 `json:"text,omitempty"`
+	ID    string                 `json:"id,omitempty"`
+	Name  string                 `json:"name,omitempty"`
+	Input map[string]interface{} `json:"input,omitempty"`
+}
+
+// ContentBlockStartEvent 对应 content_block_start SSE 事件
+type ContentBlockStartEvent struct {
+	Type         string       `json:"type"`
+	Index        int          `json:"index"`
+	ContentBlock ContentBlock `json:"content_block"`
+}
+
+// Delta 表示 content_block_delta 携带的增量内容，按 Type 区分
+// text_delta、input_json_delta 和 thinking_delta
+type Delta struct {
+	Type        string `json:"type"`
+	Text        string `json:"text,omitempty"`
+	PartialJSON string `json:"partial_json,omitempty"`
+	Thinking    string `json:"thinking,omitempty"`
+}
+
+// ContentBlockDeltaEvent 对应 content_block_delta SSE 事件
+type ContentBlockDeltaEvent struct {
+	Type  string `json:"type"`
+	Index int    `json:"index"`
+	Delta Delta  `json:"delta"`
+}
+
+// ContentBlockStopEvent 对应 content_block_stop SSE 事件
+type ContentBlockStopEvent struct {
+	Type  string `json:"type"`
+	Index int    `json:"index"`
+}
+
+// MessageDeltaEvent 对应 message_delta SSE 事件
+type MessageDeltaEvent struct {
+	Type  string `json:"type"`
+	Delta struct {
+		StopReason   string `json:"stop_reason"`
+		StopSequence string `json:"stop_sequence"`
+	} `json:"delta"`
+	Usage struct {
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage"`
+}
+
+// MessageStopEvent 对应 message_stop SSE 事件
+type MessageStopEvent struct {
+	Type string `json:"type"`
+}
+
+// PingEvent 对应 ping SSE 事件
+type PingEvent struct {
+	Type string `json:"type"`
+}
+
+// ErrorEvent 对应 error SSE 事件
+type ErrorEvent struct {
+	Type  string `json:"type"`
+	Error struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// Event 是解码后的 SSE 事件的判别联合，只有 Type 对应的字段会被填充
+type Event struct {
+	Type              EventType
+	MessageStart      *MessageStartEvent
+	ContentBlockStart *ContentBlockStartEvent
+	ContentBlockDelta *ContentBlockDeltaEvent
+	ContentBlockStop  *ContentBlockStopEvent
+	MessageDelta      *MessageDeltaEvent
+	MessageStop       *MessageStopEvent
+	Ping              *PingEvent
+	Error             *ErrorEvent
+}
+
+// DecodeOptions 控制 MessageSSEDecoder 的解码行为
+type DecodeOptions struct {
+	// ContentOnly 为 true 时，Decode 只返回文本增量事件，并把 Delta.Text
+	// 替换为从流起始位置累积拼接出的完整助手文本
+	ContentOnly bool
+}
+
+// MessageSSEDecoder 从下游 Reader 中读取 Anthropic 风格的 SSE 流，
+// 并将其解码为带类型的事件，与 Build* 系列函数的输出格式对称
+type MessageSSEDecoder struct {
+	reader *bufio.Reader
+	text   strings.Builder
+}
+
+// NewMessageSSEDecoder 基于 r 创建一个 MessageSSEDecoder
+func NewMessageSSEDecoder(r io.Reader) *MessageSSEDecoder {
+	return &MessageSSEDecoder{reader: bufio.NewReader(r)}
+}
+
+// Decode 读取并返回流中的下一个事件，流结束时返回 io.EOF
+func (d *MessageSSEDecoder) Decode(opts DecodeOptions) (*Event, error) {
+	for {
+		eventName, data, err := d.readFrame()
+		if err != nil {
+			return nil, err
+		}
+		if eventName == "" {
+			continue
+		}
+
+		event, err := decodeEvent(EventType(eventName), data)
+		if err != nil {
+			return nil, err
+		}
+
+		if opts.ContentOnly {
+			if event.ContentBlockDelta == nil || event.ContentBlockDelta.Delta.Type != "text_delta" {
+				continue
+			}
+			d.text.WriteString(event.ContentBlockDelta.Delta.Text)
+			event.ContentBlockDelta.Delta.Text = d.text.String()
+		}
+
+		return event, nil
+	}
+}
+
+// readFrame 从缓冲读取器中扫描一个 SSE 帧（event:/data: 行，以空行结束）
+func (d *MessageSSEDecoder) readFrame() (string, []byte, error) {
+	var eventName string
+	var dataLines []string
+
+	for {
+		line, err := d.reader.ReadString('\n')
+		trimmed := strings.TrimRight(line, "\r\n")
+
+		switch {
+		case strings.HasPrefix(trimmed, "event:"):
+			eventName = strings.TrimSpace(strings.TrimPrefix(trimmed, "event:"))
+		case strings.HasPrefix(trimmed, "data:"):
+			dataLines = append(dataLines, strings.TrimSpace(strings.TrimPrefix(trimmed, "data:")))
+		case trimmed == "" && eventName != "":
+			return eventName, []byte(strings.Join(dataLines, "\n")), nil
+		}
+
+		if err != nil {
+			if err == io.EOF {
+				if eventName != "" {
+					return eventName, []byte(strings.Join(dataLines, "\n")), nil
+				}
+				return "", nil, io.EOF
+			}
+			return "", nil, err
+		}
+	}
+}
+
+// decodeEvent 根据事件名称将 JSON 数据解析为对应的带类型事件
+func decodeEvent(eventName EventType, data []byte) (*Event, error) {
+	event := &Event{Type: eventName}
+
+	switch eventName {
+	case EventMessageStart:
+		var e MessageStartEvent
+		if err := json.Unmarshal(data, &e); err != nil {
+			return nil, err
+		}
+		event.MessageStart = &e
+	case EventContentBlockStart:
+		var e ContentBlockStartEvent
+		if err := json.Unmarshal(data, &e); err != nil {
+			return nil, err
+		}
+		event.ContentBlockStart = &e
+	case EventContentBlockDelta:
+		var e ContentBlockDeltaEvent
+		if err := json.Unmarshal(data, &e); err != nil {
+			return nil, err
+		}
+		event.ContentBlockDelta = &e
+	case EventContentBlockStop:
+		var e ContentBlockStopEvent
+		if err := json.Unmarshal(data, &e); err != nil {
+			return nil, err
+		}
+		event.ContentBlockStop = &e
+	case EventMessageDelta:
+		var e MessageDeltaEvent
+		if err := json.Unmarshal(data, &e); err != nil {
+			return nil, err
+		}
+		event.MessageDelta = &e
+	case EventMessageStop:
+		var e MessageStopEvent
+		if err := json.Unmarshal(data, &e); err != nil {
+			return nil, err
+		}
+		event.MessageStop = &e
+	case EventPing:
+		var e PingEvent
+		if err := json.Unmarshal(data, &e); err != nil {
+			return nil, err
+		}
+		event.Ping = &e
+	case EventError:
+		var e ErrorEvent
+		if err := json.Unmarshal(data, &e); err != nil {
+			return nil, err
+		}
+		event.Error = &e
+	}
+
+	return event, nil
+}
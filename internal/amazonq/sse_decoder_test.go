@@ -0,0 +1,93 @@
+package amazonq
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestMessageSSEDecoder_RoundTripsBuildOutput(t *testing.T) {
+	var sb strings.Builder
+	sb.WriteString(BuildMessageStart("msg_1", "amazonq-v1", 10))
+	sb.WriteString(BuildContentBlockStart(0, "text"))
+	sb.WriteString(BuildContentBlockDelta(0, "hello"))
+	sb.WriteString(BuildContentBlockDelta(0, " world"))
+	sb.WriteString(BuildContentBlockStop(0))
+	sb.WriteString(BuildMessageStop(10, 2, nil))
+
+	decoder := NewMessageSSEDecoder(strings.NewReader(sb.String()))
+
+	event, err := decoder.Decode(DecodeOptions{})
+	if err != nil {
+		t.Fatalf("decode message_start: %v", err)
+	}
+	if event.Type != EventMessageStart || event.MessageStart == nil {
+		t.Fatalf("expected message_start event, got %+v", event)
+	}
+	if event.MessageStart.Message.ID != "msg_1" {
+		t.Fatalf("unexpected message id: %q", event.MessageStart.Message.ID)
+	}
+
+	event, err = decoder.Decode(DecodeOptions{})
+	if err != nil {
+		t.Fatalf("decode content_block_start: %v", err)
+	}
+	if event.Type != EventContentBlockStart || event.ContentBlockStart.ContentBlock.Type != "text" {
+		t.Fatalf("unexpected content_block_start event: %+v", event)
+	}
+
+	var text string
+	for i := 0; i < 2; i++ {
+		event, err = decoder.Decode(DecodeOptions{})
+		if err != nil {
+			t.Fatalf("decode content_block_delta %d: %v", i, err)
+		}
+		if event.Type != EventContentBlockDelta || event.ContentBlockDelta.Delta.Type != "text_delta" {
+			t.Fatalf("unexpected content_block_delta event: %+v", event)
+		}
+		text += event.ContentBlockDelta.Delta.Text
+	}
+	if text != "hello world" {
+		t.Fatalf("unexpected reassembled text: %q", text)
+	}
+
+	if event, err = decoder.Decode(DecodeOptions{}); err != nil || event.Type != EventContentBlockStop {
+		t.Fatalf("unexpected content_block_stop event: %+v, err=%v", event, err)
+	}
+
+	if event, err = decoder.Decode(DecodeOptions{}); err != nil || event.Type != EventMessageDelta {
+		t.Fatalf("unexpected message_delta event: %+v, err=%v", event, err)
+	}
+
+	if event, err = decoder.Decode(DecodeOptions{}); err != nil || event.Type != EventMessageStop {
+		t.Fatalf("unexpected message_stop event: %+v, err=%v", event, err)
+	}
+
+	if _, err := decoder.Decode(DecodeOptions{}); err != io.EOF {
+		t.Fatalf("expected io.EOF at end of stream, got %v", err)
+	}
+}
+
+func TestMessageSSEDecoder_ContentOnlyAccumulatesText(t *testing.T) {
+	var sb strings.Builder
+	sb.WriteString(BuildContentBlockDelta(0, "foo"))
+	sb.WriteString(BuildContentBlockDelta(0, "bar"))
+
+	decoder := NewMessageSSEDecoder(strings.NewReader(sb.String()))
+
+	event, err := decoder.Decode(DecodeOptions{ContentOnly: true})
+	if err != nil {
+		t.Fatalf("decode first delta: %v", err)
+	}
+	if event.ContentBlockDelta.Delta.Text != "foo" {
+		t.Fatalf("unexpected accumulated text: %q", event.ContentBlockDelta.Delta.Text)
+	}
+
+	event, err = decoder.Decode(DecodeOptions{ContentOnly: true})
+	if err != nil {
+		t.Fatalf("decode second delta: %v", err)
+	}
+	if event.ContentBlockDelta.Delta.Text != "foobar" {
+		t.Fatalf("unexpected accumulated text: %q", event.ContentBlockDelta.Delta.Text)
+	}
+}
@@ -0,0 +1,127 @@
+package amazonq
+
+import "sync"
+
+// ToolCall 表示聚合过程中收集到的一次工具调用
+type ToolCall struct {
+	ID       string
+	Name     string
+	Input    map[string]interface{}
+	Complete bool
+}
+
+// ConversationView 是 StreamAggregator 对一次完整对话流聚合出的视图
+type ConversationView struct {
+	Text         string
+	Thinking     string
+	ToolCalls    []*ToolCall
+	InputTokens  int
+	OutputTokens int
+}
+
+// StreamAggregator 消费 ParseStream 产生的 *EventStreamMessage，
+// 按 Amazon Q 的 :event-type 重建出文本、思考过程、工具调用与用量的聚合视图
+type StreamAggregator struct {
+	mu          sync.Mutex
+	view        ConversationView
+	accumulator *ToolInputAccumulator
+	toolIndex   map[string]int // toolUseId -> view.ToolCalls 下标
+}
+
+// NewStreamAggregator 创建一个空的 StreamAggregator
+func NewStreamAggregator() *StreamAggregator {
+	return &StreamAggregator{
+		accumulator: NewToolInputAccumulator(),
+		toolIndex:   make(map[string]int),
+	}
+}
+
+// Feed 处理从 ParseStream 收到的一条消息，将其合并进聚合视图
+func (a *StreamAggregator) Feed(message *EventStreamMessage) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	info := ExtractEventInfo(message)
+	payload, _ := message.Payload.(map[string]interface{})
+	if payload == nil {
+		return
+	}
+
+	switch info.EventType {
+	case "assistantResponseEvent":
+		if content, ok := payload["content"].(string); ok {
+			a.view.Text += content
+		}
+	case "thinkingEvent":
+		if content, ok := payload["content"].(string); ok {
+			a.view.Thinking += content
+		}
+	case "toolUseEvent":
+		a.feedToolUse(payload)
+	case "messageMetadataEvent":
+		a.feedUsage(payload)
+	}
+}
+
+// feedToolUse 合并一条 toolUseEvent，按 toolUseId 定位或创建对应的 ToolCall，
+// 并把 input 片段交给 ToolInputAccumulator 做增量解析
+func (a *StreamAggregator) feedToolUse(payload map[string]interface{}) {
+	toolUseID, _ := payload["toolUseId"].(string)
+	if toolUseID == "" {
+		return
+	}
+
+	idx, ok := a.toolIndex[toolUseID]
+	if !ok {
+		idx = len(a.view.ToolCalls)
+		a.toolIndex[toolUseID] = idx
+		name, _ := payload["name"].(string)
+		a.view.ToolCalls = append(a.view.ToolCalls, &ToolCall{ID: toolUseID, Name: name})
+	}
+
+	if input, ok := payload["input"].(string); ok && input != "" {
+		a.accumulator.AddDelta(idx, input)
+	}
+
+	if stop, _ := payload["stop"].(bool); stop {
+		a.view.ToolCalls[idx].Input = a.accumulator.Stop(idx)
+		a.view.ToolCalls[idx].Complete = true
+		return
+	}
+
+	partial, _ := a.accumulator.Snapshot(idx)
+	a.view.ToolCalls[idx].Input = partial
+}
+
+// feedUsage 合并 messageMetadataEvent 中携带的 token 用量
+func (a *StreamAggregator) feedUsage(payload map[string]interface{}) {
+	usage, ok := payload["usage"].(map[string]interface{})
+	if !ok {
+		return
+	}
+	if v, ok := usage["inputTokens"].(float64); ok {
+		a.view.InputTokens = int(v)
+	}
+	if v, ok := usage["outputTokens"].(float64); ok {
+		a.view.OutputTokens = int(v)
+	}
+}
+
+// View 返回当前已聚合出的对话视图的快照。返回的每个 ToolCall 都是结构体副本，
+// Feed 之后对内部 ToolCall 的修改不会反映到快照中，调用方可以在不持锁的情况下安全读取。
+// ToolCall.Input 这个 map 本身并未复制，与内部状态共享；Feed 只会整体替换 Input，
+// 不会原地修改已有的 map
+func (a *StreamAggregator) View() ConversationView {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	toolCalls := make([]*ToolCall, len(a.view.ToolCalls))
+	for i, tc := range a.view.ToolCalls {
+		copied := *tc
+		toolCalls[i] = &copied
+	}
+
+	view := a.view
+	view.ToolCalls = toolCalls
+	return view
+}
@@ -0,0 +1,90 @@
+package amazonq
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestStreamAggregator_FeedAggregatesTextThinkingAndUsage(t *testing.T) {
+	agg := NewStreamAggregator()
+
+	agg.Feed(&EventStreamMessage{
+		Headers: map[string]string{":event-type": "thinkingEvent"},
+		Payload: map[string]interface{}{"content": "let me check..."},
+	})
+	agg.Feed(&EventStreamMessage{
+		Headers: map[string]string{":event-type": "assistantResponseEvent"},
+		Payload: map[string]interface{}{"content": "hello "},
+	})
+	agg.Feed(&EventStreamMessage{
+		Headers: map[string]string{":event-type": "assistantResponseEvent"},
+		Payload: map[string]interface{}{"content": "world"},
+	})
+	agg.Feed(&EventStreamMessage{
+		Headers: map[string]string{":event-type": "messageMetadataEvent"},
+		Payload: map[string]interface{}{
+			"usage": map[string]interface{}{"inputTokens": float64(7), "outputTokens": float64(3)},
+		},
+	})
+
+	view := agg.View()
+	if view.Thinking != "let me check..." {
+		t.Fatalf("unexpected thinking: %q", view.Thinking)
+	}
+	if view.Text != "hello world" {
+		t.Fatalf("unexpected text: %q", view.Text)
+	}
+	if view.InputTokens != 7 || view.OutputTokens != 3 {
+		t.Fatalf("unexpected usage: inputTokens=%d outputTokens=%d", view.InputTokens, view.OutputTokens)
+	}
+}
+
+// TestStreamAggregator_FeedAndViewConcurrently is a regression test for the
+// data race fixed in View(): a caller reading a previously returned
+// ConversationView must not share memory with ToolCall structs that Feed is
+// still mutating on another goroutine. Run with -race to catch regressions.
+func TestStreamAggregator_FeedAndViewConcurrently(t *testing.T) {
+	agg := NewStreamAggregator()
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 200; i++ {
+			agg.Feed(&EventStreamMessage{
+				Headers: map[string]string{":event-type": "toolUseEvent"},
+				Payload: map[string]interface{}{
+					"toolUseId": "t1",
+					"name":      "search",
+					"input":     `{"q":"x"}`,
+					"stop":      i == 199,
+				},
+			})
+		}
+	}()
+
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 200; i++ {
+			view := agg.View()
+			for _, tc := range view.ToolCalls {
+				_ = tc.Input
+				_ = tc.Complete
+			}
+		}
+	}()
+
+	wg.Wait()
+
+	final := agg.View()
+	if len(final.ToolCalls) != 1 {
+		t.Fatalf("expected exactly one tool call, got %d", len(final.ToolCalls))
+	}
+	if !final.ToolCalls[0].Complete {
+		t.Fatalf("expected tool call to be complete")
+	}
+	if final.ToolCalls[0].Input["q"] != "x" {
+		t.Fatalf("unexpected tool input: %+v", final.ToolCalls[0].Input)
+	}
+}
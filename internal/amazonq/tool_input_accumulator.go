@@ -0,0 +1,81 @@
+package amazonq
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+)
+
+// toolInputBuffer 维护单个 content_block 索引对应的 tool_use 参数累积状态
+type toolInputBuffer struct {
+	raw      strings.Builder
+	snapshot map[string]interface{}
+	complete bool
+}
+
+// tryParse 尝试将当前累积的缓冲区解析为 JSON 对象。解析失败（例如遇到括号/引号
+// 不匹配的中间态）时保留上一次成功解析出的快照，不向调用方暴露错误
+func (b *toolInputBuffer) tryParse() {
+	var parsed map[string]interface{}
+	if err := json.Unmarshal([]byte(b.raw.String()), &parsed); err != nil {
+		return
+	}
+	b.snapshot = parsed
+}
+
+// ToolInputAccumulator 按 content_block 索引缓冲 input_json_delta 的 partial_json
+// 片段，在每个增量边界尝试增量解析，并在 content_block_stop 时给出最终的参数对象
+type ToolInputAccumulator struct {
+	mu      sync.Mutex
+	buffers map[int]*toolInputBuffer
+}
+
+// NewToolInputAccumulator 创建一个空的 ToolInputAccumulator
+func NewToolInputAccumulator() *ToolInputAccumulator {
+	return &ToolInputAccumulator{buffers: make(map[int]*toolInputBuffer)}
+}
+
+// AddDelta 追加 index 对应内容块的一个 partial_json 片段，
+// 并尝试对累积至今的缓冲区做一次增量解析
+func (a *ToolInputAccumulator) AddDelta(index int, partialJSON string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	buf := a.bufferFor(index)
+	buf.raw.WriteString(partialJSON)
+	buf.tryParse()
+}
+
+// Stop 标记 index 对应的内容块已结束，并返回最终解析出的参数对象
+// 若累积的缓冲区不是合法 JSON，返回的 map 为最后一次成功解析的快照（可能为 nil）
+func (a *ToolInputAccumulator) Stop(index int) map[string]interface{} {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	buf := a.bufferFor(index)
+	buf.tryParse()
+	buf.complete = true
+	return buf.snapshot
+}
+
+// Snapshot 返回 index 对应内容块当前已知的参数快照
+// complete 指示该内容块是否已经收到 content_block_stop
+func (a *ToolInputAccumulator) Snapshot(index int) (partial map[string]interface{}, complete bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	buf, ok := a.buffers[index]
+	if !ok {
+		return nil, false
+	}
+	return buf.snapshot, buf.complete
+}
+
+func (a *ToolInputAccumulator) bufferFor(index int) *toolInputBuffer {
+	buf, ok := a.buffers[index]
+	if !ok {
+		buf = &toolInputBuffer{}
+		a.buffers[index] = buf
+	}
+	return buf
+}
@@ -0,0 +1,53 @@
+package amazonq
+
+import "testing"
+
+func TestToolInputAccumulator_RecoversFromMalformedIntermediateState(t *testing.T) {
+	acc := NewToolInputAccumulator()
+
+	acc.AddDelta(0, `{"query": "weat`)
+	if partial, complete := acc.Snapshot(0); partial != nil || complete {
+		t.Fatalf("expected no parseable snapshot yet, got %+v complete=%v", partial, complete)
+	}
+
+	acc.AddDelta(0, `her", "units": "c`)
+	if partial, _ := acc.Snapshot(0); partial != nil {
+		t.Fatalf("expected still-unparseable snapshot, got %+v", partial)
+	}
+
+	acc.AddDelta(0, `elsius"}`)
+	partial, complete := acc.Snapshot(0)
+	if complete {
+		t.Fatalf("expected complete to stay false before Stop")
+	}
+	if partial["query"] != "weather" || partial["units"] != "celsius" {
+		t.Fatalf("unexpected parsed snapshot: %+v", partial)
+	}
+
+	final := acc.Stop(0)
+	if final["query"] != "weather" || final["units"] != "celsius" {
+		t.Fatalf("unexpected final result: %+v", final)
+	}
+	if _, complete := acc.Snapshot(0); !complete {
+		t.Fatalf("expected complete to be true after Stop")
+	}
+}
+
+func TestToolInputAccumulator_TracksIndexesIndependently(t *testing.T) {
+	acc := NewToolInputAccumulator()
+
+	acc.AddDelta(0, `{"a":1}`)
+	acc.AddDelta(1, `{"b":2`)
+
+	if partial, _ := acc.Snapshot(0); partial["a"] != float64(1) {
+		t.Fatalf("unexpected snapshot for index 0: %+v", partial)
+	}
+	if partial, _ := acc.Snapshot(1); partial != nil {
+		t.Fatalf("expected index 1 to still be unparseable, got %+v", partial)
+	}
+
+	acc.AddDelta(1, `}`)
+	if partial, _ := acc.Snapshot(1); partial["b"] != float64(2) {
+		t.Fatalf("unexpected snapshot for index 1: %+v", partial)
+	}
+}
@@ -0,0 +1,269 @@
+package amazonq
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// TranscoderOptions 配置 Transcoder 的行为
+type TranscoderOptions struct {
+	// ConversationID 写入 message_start 事件的会话 ID
+	ConversationID string
+	// Model 写入 message_start 事件的模型名称
+	Model string
+	// HeartbeatInterval 是两次 ping 事件之间的最大间隔，零值表示不发送心跳
+	HeartbeatInterval time.Duration
+}
+
+// blockKind 标识 Transcoder 当前正在输出的 content_block 种类
+type blockKind int
+
+const (
+	blockKindNone blockKind = iota
+	blockKindText
+	blockKindThinking
+	blockKindToolUse
+)
+
+// transcodeState 跟踪 Transcoder 当前正在输出的 content_block 及累计用量
+type transcodeState struct {
+	index        int
+	kind         blockKind
+	toolUseID    string
+	inputTokens  int
+	outputTokens int
+}
+
+// Transcoder 把上游的 Amazon Q Event Stream 转换为 Anthropic 兼容的 SSE 响应
+type Transcoder struct {
+	Options TranscoderOptions
+}
+
+// NewTranscoder 创建一个使用 opts 配置的 Transcoder
+func NewTranscoder(opts TranscoderOptions) *Transcoder {
+	return &Transcoder{Options: opts}
+}
+
+// Pipe 从 upstream 读取 AWS Event Stream 帧，转换为 Anthropic 风格的 SSE 事件写入
+// downstream，直到上游结束、ctx 被取消或发生错误。ParseStream 本身不感知 ctx，
+// 会一直阻塞在 upstream.Read 上；如果 upstream 同时实现了 io.Closer，ctx 被取消时
+// Pipe 会调用 Close 来唤醒那次阻塞的读取，避免解析 goroutine 泄漏
+func (t *Transcoder) Pipe(ctx context.Context, upstream io.Reader, downstream io.Writer) error {
+	eventChan := make(chan *EventStreamMessage, 16)
+	parseErrChan := make(chan error, 1)
+
+	go func() {
+		parseErrChan <- ParseStream(upstream, eventChan)
+	}()
+
+	if closer, ok := upstream.(io.Closer); ok {
+		done := make(chan struct{})
+		defer close(done)
+		go func() {
+			select {
+			case <-ctx.Done():
+				closer.Close()
+			case <-done:
+				// ctx 取消后 Pipe 会立即返回并关闭 done；若此时本 goroutine 才进入
+				// select，两个分支同时就绪，select 可能选中 done，这里补上 Close
+				if ctx.Err() != nil {
+					closer.Close()
+				}
+			}
+		}()
+	}
+
+	if err := writeSSE(downstream, BuildMessageStart(t.Options.ConversationID, t.Options.Model, 0)); err != nil {
+		return err
+	}
+
+	state := &transcodeState{index: -1, kind: blockKindNone}
+
+	var heartbeat <-chan time.Time
+	if t.Options.HeartbeatInterval > 0 {
+		ticker := time.NewTicker(t.Options.HeartbeatInterval)
+		defer ticker.Stop()
+		heartbeat = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			// ParseStream 的发送 goroutine 可能已经把 eventChan 的缓冲区（16 条）
+			// 填满并阻塞在下一次 eventChan <- message 上——closer.Close() 只负责
+			// 唤醒阻塞的 Read，还需要继续排空 eventChan，发送方才能真正退出
+			go drainEventChan(eventChan)
+			return ctx.Err()
+		case <-heartbeat:
+			if err := writeSSE(downstream, BuildPing()); err != nil {
+				return err
+			}
+		case message, ok := <-eventChan:
+			if !ok {
+				return t.finish(downstream, state, <-parseErrChan)
+			}
+			if err := t.handleMessage(downstream, state, message); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// drainEventChan 持续消费 ch 直到其关闭，用来在 Pipe 提前返回后
+// 解除 ParseStream 发送 goroutine 可能阻塞在 ch <- message 上的状态
+func drainEventChan(ch <-chan *EventStreamMessage) {
+	for range ch {
+	}
+}
+
+// handleMessage 根据上游消息的 :event-type 转发对应的 content_block 增量，
+// 并在事件类型发生变化时切换当前输出中的 content_block
+func (t *Transcoder) handleMessage(w io.Writer, state *transcodeState, message *EventStreamMessage) error {
+	info := ExtractEventInfo(message)
+	payload, _ := message.Payload.(map[string]interface{})
+	if payload == nil {
+		return nil
+	}
+
+	switch info.EventType {
+	case "assistantResponseEvent":
+		content, _ := payload["content"].(string)
+		if content == "" {
+			return nil
+		}
+		if err := t.ensureBlock(w, state, blockKindText); err != nil {
+			return err
+		}
+		return writeSSE(w, BuildContentBlockDelta(state.index, content))
+
+	case "thinkingEvent":
+		content, _ := payload["content"].(string)
+		if content == "" {
+			return nil
+		}
+		if err := t.ensureBlock(w, state, blockKindThinking); err != nil {
+			return err
+		}
+		return writeSSE(w, buildThinkingDelta(state.index, content))
+
+	case "toolUseEvent":
+		return t.handleToolUse(w, state, payload)
+
+	case "messageMetadataEvent":
+		t.applyUsage(state, payload)
+	}
+
+	return nil
+}
+
+// ensureBlock 确保当前正在输出 kind 对应种类的 content_block，
+// 如果当前打开的是另一种块则先发出 content_block_stop
+func (t *Transcoder) ensureBlock(w io.Writer, state *transcodeState, kind blockKind) error {
+	if state.kind == kind {
+		return nil
+	}
+	if state.kind != blockKindNone {
+		if err := writeSSE(w, BuildContentBlockStop(state.index)); err != nil {
+			return err
+		}
+	}
+
+	state.index++
+	state.kind = kind
+	state.toolUseID = ""
+
+	blockType := "text"
+	if kind == blockKindThinking {
+		blockType = "thinking"
+	}
+	return writeSSE(w, BuildContentBlockStart(state.index, blockType))
+}
+
+// handleToolUse 处理一条 toolUseEvent：在 toolUseId 变化时开启新的 tool_use
+// content_block，转发 input 片段，并在 stop 为 true 时关闭该块
+func (t *Transcoder) handleToolUse(w io.Writer, state *transcodeState, payload map[string]interface{}) error {
+	toolUseID, _ := payload["toolUseId"].(string)
+	if toolUseID == "" {
+		return nil
+	}
+
+	if state.kind != blockKindToolUse || state.toolUseID != toolUseID {
+		if state.kind != blockKindNone {
+			if err := writeSSE(w, BuildContentBlockStop(state.index)); err != nil {
+				return err
+			}
+		}
+		name, _ := payload["name"].(string)
+		state.index++
+		state.kind = blockKindToolUse
+		state.toolUseID = toolUseID
+		if err := writeSSE(w, BuildToolUseStart(state.index, toolUseID, name)); err != nil {
+			return err
+		}
+	}
+
+	if input, ok := payload["input"].(string); ok && input != "" {
+		if err := writeSSE(w, BuildToolUseInputDelta(state.index, input)); err != nil {
+			return err
+		}
+	}
+
+	if stop, _ := payload["stop"].(bool); stop {
+		if err := writeSSE(w, BuildContentBlockStop(state.index)); err != nil {
+			return err
+		}
+		state.kind = blockKindNone
+	}
+
+	return nil
+}
+
+// applyUsage 从 messageMetadataEvent 中合并累计的 token 用量
+func (t *Transcoder) applyUsage(state *transcodeState, payload map[string]interface{}) {
+	usage, ok := payload["usage"].(map[string]interface{})
+	if !ok {
+		return
+	}
+	if v, ok := usage["inputTokens"].(float64); ok {
+		state.inputTokens = int(v)
+	}
+	if v, ok := usage["outputTokens"].(float64); ok {
+		state.outputTokens = int(v)
+	}
+}
+
+// finish 关闭尚未结束的 content_block 并发出 message_delta/message_stop，
+// 随后把上游 ParseStream 返回的错误（如果有）传给调用方
+func (t *Transcoder) finish(w io.Writer, state *transcodeState, parseErr error) error {
+	if state.kind != blockKindNone {
+		if err := writeSSE(w, BuildContentBlockStop(state.index)); err != nil {
+			return err
+		}
+		state.kind = blockKindNone
+	}
+	if err := writeSSE(w, BuildMessageStop(state.inputTokens, state.outputTokens, nil)); err != nil {
+		return err
+	}
+	return parseErr
+}
+
+// buildThinkingDelta 构建 thinking_delta 的 content_block_delta SSE 事件，
+// 与 BuildContentBlockDelta（text_delta）和 BuildToolUseInputDelta（input_json_delta）对称
+func buildThinkingDelta(index int, text string) string {
+	data := map[string]interface{}{
+		"type":  "content_block_delta",
+		"index": index,
+		"delta": map[string]string{
+			"type":     "thinking_delta",
+			"thinking": text,
+		},
+	}
+	return FormatSSE("content_block_delta", data)
+}
+
+// writeSSE 将已格式化的 SSE 文本写入 w
+func writeSSE(w io.Writer, s string) error {
+	_, err := io.WriteString(w, s)
+	return err
+}
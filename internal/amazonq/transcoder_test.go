@@ -0,0 +1,113 @@
+package amazonq
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTranscoder_PipeHappyPath(t *testing.T) {
+	var upstream bytes.Buffer
+	writer := NewEventStreamWriter(&upstream)
+
+	if err := writer.WriteMessage(map[string]HeaderValue{
+		":event-type": {Type: HeaderValueTypeString, StringVal: "assistantResponseEvent"},
+	}, []byte(`{"content":"hi"}`)); err != nil {
+		t.Fatalf("WriteMessage: %v", err)
+	}
+	if err := writer.WriteMessage(map[string]HeaderValue{
+		":event-type": {Type: HeaderValueTypeString, StringVal: "messageMetadataEvent"},
+	}, []byte(`{"usage":{"inputTokens":5,"outputTokens":2}}`)); err != nil {
+		t.Fatalf("WriteMessage: %v", err)
+	}
+
+	var downstream bytes.Buffer
+	tr := NewTranscoder(TranscoderOptions{ConversationID: "conv_1", Model: "amazonq-v1"})
+
+	if err := tr.Pipe(context.Background(), &upstream, &downstream); err != nil {
+		t.Fatalf("Pipe: %v", err)
+	}
+
+	decoder := NewMessageSSEDecoder(strings.NewReader(downstream.String()))
+
+	event, err := decoder.Decode(DecodeOptions{})
+	if err != nil || event.Type != EventMessageStart {
+		t.Fatalf("expected message_start, got %+v err=%v", event, err)
+	}
+	if event.MessageStart.Message.ID != "conv_1" || event.MessageStart.Message.Model != "amazonq-v1" {
+		t.Fatalf("unexpected message_start fields: %+v", event.MessageStart.Message)
+	}
+
+	event, err = decoder.Decode(DecodeOptions{})
+	if err != nil || event.Type != EventContentBlockStart || event.ContentBlockStart.ContentBlock.Type != "text" {
+		t.Fatalf("expected content_block_start(text), got %+v err=%v", event, err)
+	}
+
+	event, err = decoder.Decode(DecodeOptions{})
+	if err != nil || event.Type != EventContentBlockDelta || event.ContentBlockDelta.Delta.Text != "hi" {
+		t.Fatalf("expected content_block_delta(hi), got %+v err=%v", event, err)
+	}
+
+	event, err = decoder.Decode(DecodeOptions{})
+	if err != nil || event.Type != EventContentBlockStop {
+		t.Fatalf("expected content_block_stop, got %+v err=%v", event, err)
+	}
+
+	event, err = decoder.Decode(DecodeOptions{})
+	if err != nil || event.Type != EventMessageDelta {
+		t.Fatalf("expected message_delta, got %+v err=%v", event, err)
+	}
+	if event.MessageDelta.Usage.OutputTokens != 2 {
+		t.Fatalf("unexpected output tokens: %+v", event.MessageDelta.Usage)
+	}
+
+	event, err = decoder.Decode(DecodeOptions{})
+	if err != nil || event.Type != EventMessageStop {
+		t.Fatalf("expected message_stop, got %+v err=%v", event, err)
+	}
+
+	if _, err := decoder.Decode(DecodeOptions{}); err != io.EOF {
+		t.Fatalf("expected io.EOF at end of stream, got %v", err)
+	}
+}
+
+// TestTranscoder_PipeDrainsEventChanBacklogOnCancel is a regression test for a
+// goroutine leak: ParseStream's sender goroutine can decode a burst of frames
+// larger than eventChan's buffer from a single upstream Read, and blocks on
+// eventChan <- message. Closing upstream alone unblocks the Read but not that
+// blocked send, so Pipe must also drain eventChan after ctx is cancelled.
+func TestTranscoder_PipeDrainsEventChanBacklogOnCancel(t *testing.T) {
+	var upstream bytes.Buffer
+	writer := NewEventStreamWriter(&upstream)
+	headers := map[string]HeaderValue{
+		":event-type": {Type: HeaderValueTypeString, StringVal: "assistantResponseEvent"},
+	}
+	for i := 0; i < 200; i++ {
+		if err := writer.WriteMessage(headers, []byte(`{"content":"x"}`)); err != nil {
+			t.Fatalf("WriteMessage: %v", err)
+		}
+	}
+
+	before := runtime.NumGoroutine()
+
+	tr := NewTranscoder(TranscoderOptions{})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := tr.Pipe(ctx, &upstream, io.Discard); err == nil {
+		t.Fatalf("expected ctx.Err() from Pipe, got nil")
+	}
+
+	deadline := time.Now().Add(1 * time.Second)
+	for time.Now().Before(deadline) {
+		if runtime.NumGoroutine() <= before {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("goroutine count did not return to baseline: before=%d after=%d", before, runtime.NumGoroutine())
+}